@@ -1,33 +1,120 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fiam/stringutil"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
 )
 
 const (
 	defaultWatchdogInterval = 300
 	defaultTimeout          = 60
+	defaultMaxBody          = 1 << 20 // 1MiB
 )
 
 type dog interface {
 	check() error
 }
 
+// dogFactory builds a dog from its parsed command line, args[0] being
+// the dog name itself (e.g. "run", "get"). It mirrors the signature
+// callers already pass to helpers like getTimeout.
+type dogFactory func(args []string) (dog, error)
+
+var dogRegistry = map[string]dogFactory{}
+
+// RegisterDog makes a new probe type available to Watchdog.Parse under
+// the given name. Built-in dogs register themselves the same way, so
+// external code can add probe types without touching this file.
+func RegisterDog(name string, factory dogFactory) {
+	if _, exists := dogRegistry[name]; exists {
+		panic(fmt.Sprintf("dog %q already registered", name))
+	}
+	dogRegistry[name] = factory
+}
+
+func init() {
+	RegisterDog("run", newRunDog)
+	RegisterDog("exec", newRunDog)
+	RegisterDog("connect", newConnectDog)
+	RegisterDog("get", newGetDog)
+	RegisterDog("dns", newDNSDog)
+	RegisterDog("tls", newTLSDog)
+	RegisterDog("ping", newPingDog)
+	RegisterDog("script", newScriptDog)
+}
+
 type runDog struct {
-	argv []string
+	argv       []string
+	expectExit int
+}
+
+func newRunDog(args []string) (dog, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s watchdog requires at least one argument", args[0])
+	}
+	d := &runDog{}
+	for _, arg := range args[1:] {
+		if code, ok, err := parseExpectExit(arg); err != nil {
+			return nil, err
+		} else if ok {
+			d.expectExit = code
+			continue
+		}
+		d.argv = append(d.argv, arg)
+	}
+	if len(d.argv) == 0 {
+		return nil, fmt.Errorf("%s watchdog requires a command to run", args[0])
+	}
+	return d, nil
+}
+
+func parseExpectExit(arg string) (code int, ok bool, err error) {
+	const prefix = "--expect-exit="
+	if len(arg) <= len(prefix) || arg[:len(prefix)] != prefix {
+		return 0, false, nil
+	}
+	code, err = strconv.Atoi(arg[len(prefix):])
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid --expect-exit value %q: %s", arg[len(prefix):], err)
+	}
+	return code, true, nil
 }
 
 func (d *runDog) check() error {
 	cmd := exec.Command(d.argv[0], d.argv[1:]...)
-	return cmd.Run()
+	out, err := cmd.CombinedOutput()
+	exitCode := 0
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return err
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	if exitCode != d.expectExit {
+		return fmt.Errorf("%s exited with code %d (expected %d), output: %s", d.argv[0], exitCode, d.expectExit, out)
+	}
+	return nil
 }
 
 func (d *runDog) String() string {
@@ -67,6 +154,27 @@ type connectDog struct {
 	timeout int
 }
 
+func newConnectDog(args []string) (dog, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("connect watchdog requires one or two arguments, %d given", len(args)-1)
+	}
+	u, err := url.Parse(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid connect URL %q: %s", args[1], err)
+	}
+	if u.Scheme != "tcp" && u.Scheme != "udp" {
+		return nil, fmt.Errorf("invalid connect URL scheme %q - must be tcp or udp", u.Scheme)
+	}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return nil, fmt.Errorf("address %q must specifiy a host and a port", u.Host)
+	}
+	timeout, err := getTimeout("connect", args)
+	if err != nil {
+		return nil, err
+	}
+	return &connectDog{u.Scheme, u.Host, timeout}, nil
+}
+
 func (d *connectDog) connectProto() string {
 	if d.proto == "" {
 		return "tcp"
@@ -92,73 +200,740 @@ func (d *connectDog) String() string {
 }
 
 type getDog struct {
-	url     string
-	timeout int
+	url    string
+	method string
+	header http.Header
+	body   string
+
+	expectStatuses     []int
+	expectBodyContains string
+	expectBodyRegex    *regexp.Regexp
+	expectJSONPath     string
+	expectJSONValue    string
+
+	insecureTLS bool
+	clientCert  string
+	clientKey   string
+	maxBody     int64
+	timeout     int
+}
+
+func newGetDog(args []string) (dog, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("get watchdog requires at least a URL, %d given", len(args)-1)
+	}
+	u, err := url.Parse(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid GET URL %q: %s", args[1], err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid GET URL scheme %q - must be http or https", u.Scheme)
+	}
+	d := &getDog{
+		url:     args[1],
+		method:  "GET",
+		header:  make(http.Header),
+		maxBody: defaultMaxBody,
+	}
+	for _, arg := range args[2:] {
+		key, value, hasValue := splitKeyValue(arg)
+		switch {
+		case !hasValue:
+			timeout, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("get watchdog: unrecognized argument %q", arg)
+			}
+			d.timeout = timeout
+		case key == "method":
+			d.method = strings.ToUpper(value)
+		case key == "header":
+			parts := strings.SplitN(value, ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid header %q - expected \"Name: Value\"", value)
+			}
+			d.header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		case key == "body":
+			if strings.HasPrefix(value, "@") {
+				data, err := ioutil.ReadFile(value[1:])
+				if err != nil {
+					return nil, fmt.Errorf("could not read body file %q: %s", value[1:], err)
+				}
+				d.body = string(data)
+			} else {
+				d.body = value
+			}
+		case key == "expect-status":
+			statuses, err := parseStatusList(value)
+			if err != nil {
+				return nil, err
+			}
+			d.expectStatuses = statuses
+		case key == "expect-body-contains":
+			d.expectBodyContains = value
+		case key == "expect-body-regex":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expect-body-regex %q: %s", value, err)
+			}
+			d.expectBodyRegex = re
+		case key == "expect-json":
+			path, expected, err := splitJSONExpectation(value)
+			if err != nil {
+				return nil, err
+			}
+			d.expectJSONPath = path
+			d.expectJSONValue = expected
+		case key == "insecure-tls":
+			d.insecureTLS = value == "true"
+		case key == "client-cert":
+			d.clientCert = value
+		case key == "client-key":
+			d.clientKey = value
+		case key == "max-body":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max-body %q: %s", value, err)
+			}
+			d.maxBody = n
+		default:
+			return nil, fmt.Errorf("get watchdog: unrecognized argument %q", arg)
+		}
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	if len(d.expectStatuses) == 0 {
+		d.expectStatuses = []int{http.StatusOK}
+	}
+	return d, nil
+}
+
+// splitKeyValue splits a "key=value" watchdog argument. hasValue is false
+// for bare tokens like a positional timeout, which have no "=".
+func splitKeyValue(arg string) (key, value string, hasValue bool) {
+	idx := strings.IndexByte(arg, '=')
+	if idx < 0 {
+		return "", arg, false
+	}
+	return arg[:idx], arg[idx+1:], true
+}
+
+func parseStatusList(spec string) ([]int, error) {
+	var statuses []int
+	for _, part := range strings.Split(spec, ",") {
+		if dash := strings.IndexByte(part, '-'); dash > 0 {
+			lo, err := strconv.Atoi(part[:dash])
+			if err != nil {
+				return nil, fmt.Errorf("invalid expect-status range %q", part)
+			}
+			hi, err := strconv.Atoi(part[dash+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid expect-status range %q", part)
+			}
+			for s := lo; s <= hi; s++ {
+				statuses = append(statuses, s)
+			}
+			continue
+		}
+		s, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expect-status code %q", part)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// splitJSONExpectation parses the tiny jq-like path expression accepted
+// by expect-json, e.g. ".status==ok" into path ".status" and value "ok".
+func splitJSONExpectation(spec string) (path, value string, err error) {
+	parts := strings.SplitN(spec, "==", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expect-json must be of the form .path==value, got %q", spec)
+	}
+	return parts[0], parts[1], nil
+}
+
+func evalJSONPath(body []byte, path string) (string, error) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("invalid JSON response: %s", err)
+	}
+	for _, field := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("path %q does not resolve to an object", path)
+		}
+		data, ok = m[field]
+		if !ok {
+			return "", fmt.Errorf("field %q not found in response", field)
+		}
+	}
+	return fmt.Sprintf("%v", data), nil
+}
+
+func containsStatus(statuses []int, code int) bool {
+	for _, s := range statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
 }
 
 func (d *getDog) check() error {
-	req, err := http.NewRequest("GET", d.url, nil)
+	var bodyReader io.Reader
+	if d.body != "" {
+		bodyReader = strings.NewReader(d.body)
+	}
+	req, err := http.NewRequest(d.method, d.url, bodyReader)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("User-Agent", fmt.Sprintf("%s watchdog", AppName))
-	client := &http.Client{}
-	client.Transport = &http.Transport{
-		Dial: dialTimeout(d.timeout),
+	for name, values := range d.header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: d.insecureTLS}
+	if d.clientCert != "" || d.clientKey != "" {
+		cert, err := tls.LoadX509KeyPair(d.clientCert, d.clientKey)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Dial:            dialTimeout(d.timeout),
+			TLSClientConfig: tlsConfig,
+		},
 	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("non-200 error code %d", resp.StatusCode)
+
+	if !containsStatus(d.expectStatuses, resp.StatusCode) {
+		return fmt.Errorf("unexpected status code %d, expected one of %v", resp.StatusCode, d.expectStatuses)
+	}
+
+	if d.expectBodyContains == "" && d.expectBodyRegex == nil && d.expectJSONPath == "" {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, d.maxBody))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %s", err)
+	}
+
+	if d.expectBodyContains != "" && !strings.Contains(string(body), d.expectBodyContains) {
+		return fmt.Errorf("response body does not contain %q", d.expectBodyContains)
+	}
+	if d.expectBodyRegex != nil && !d.expectBodyRegex.Match(body) {
+		return fmt.Errorf("response body does not match %q", d.expectBodyRegex.String())
+	}
+	if d.expectJSONPath != "" {
+		got, err := evalJSONPath(body, d.expectJSONPath)
+		if err != nil {
+			return fmt.Errorf("expect-json: %s", err)
+		}
+		if got != d.expectJSONValue {
+			return fmt.Errorf("expect-json: %s = %q, expected %q", d.expectJSONPath, got, d.expectJSONValue)
+		}
 	}
 	return nil
 }
 
 func (d *getDog) String() string {
-	return fmt.Sprintf("GET: %s", d.url)
+	return fmt.Sprintf("%s: %s", d.method, d.url)
+}
+
+type dnsDog struct {
+	host    string
+	server  string
+	timeout int
+}
+
+func newDNSDog(args []string) (dog, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, fmt.Errorf("dns watchdog requires between one and three arguments, %d given", len(args)-1)
+	}
+	d := &dnsDog{host: args[1]}
+	rest := args[2:]
+	if len(rest) > 0 {
+		if _, err := strconv.Atoi(rest[0]); err != nil {
+			d.server = rest[0]
+			rest = rest[1:]
+		}
+	}
+	if len(rest) > 0 {
+		timeout, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return nil, fmt.Errorf("dns watchdog timeout must be integer, not %s", rest[0])
+		}
+		d.timeout = timeout
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	return d, nil
+}
+
+func (d *dnsDog) check() error {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			addr := address
+			if d.server != "" {
+				addr = d.server
+				if _, _, err := net.SplitHostPort(addr); err != nil {
+					addr = net.JoinHostPort(addr, "53")
+				}
+			}
+			return dialTimeout(d.timeout)(network, addr)
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(d.timeout))
+	defer cancel()
+	addrs, err := resolver.LookupHost(ctx, d.host)
+	if err != nil {
+		return fmt.Errorf("dns lookup for %s failed: %s", d.host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("dns lookup for %s returned no addresses", d.host)
+	}
+	return nil
+}
+
+func (d *dnsDog) String() string {
+	return fmt.Sprintf("dns: %s", d.host)
+}
+
+type tlsDog struct {
+	addr    string
+	days    int
+	timeout int
+}
+
+func newTLSDog(args []string) (dog, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, fmt.Errorf("tls watchdog requires between one and three arguments, %d given", len(args)-1)
+	}
+	if _, _, err := net.SplitHostPort(args[1]); err != nil {
+		return nil, fmt.Errorf("address %q must specify a host and a port", args[1])
+	}
+	d := &tlsDog{addr: args[1]}
+	if len(args) > 2 {
+		days, err := strconv.Atoi(args[2])
+		if err != nil {
+			return nil, fmt.Errorf("tls watchdog days must be integer, not %s", args[2])
+		}
+		d.days = days
+	}
+	if len(args) > 3 {
+		timeout, err := strconv.Atoi(args[3])
+		if err != nil {
+			return nil, fmt.Errorf("tls watchdog timeout must be integer, not %s", args[3])
+		}
+		d.timeout = timeout
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	return d, nil
+}
+
+func (d *tlsDog) check() error {
+	dialer := &net.Dialer{Timeout: time.Second * time.Duration(d.timeout)}
+	conn, err := tls.DialWithDialer(dialer, "tcp", d.addr, &tls.Config{})
+	if err != nil {
+		return fmt.Errorf("tls dial to %s failed: %s", d.addr, err)
+	}
+	defer conn.Close()
+	if d.days <= 0 {
+		return nil
+	}
+	threshold := time.Now().Add(time.Duration(d.days) * 24 * time.Hour)
+	for _, cert := range conn.ConnectionState().PeerCertificates {
+		if cert.NotAfter.Before(threshold) {
+			return fmt.Errorf("certificate %q for %s expires on %s, in less than %d days", cert.Subject.CommonName, d.addr, cert.NotAfter, d.days)
+		}
+	}
+	return nil
+}
+
+func (d *tlsDog) String() string {
+	return fmt.Sprintf("tls: %s", d.addr)
+}
+
+type pingDog struct {
+	host    string
+	count   int
+	timeout int
+}
+
+func newPingDog(args []string) (dog, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, fmt.Errorf("ping watchdog requires between one and three arguments, %d given", len(args)-1)
+	}
+	d := &pingDog{host: args[1], count: 1}
+	if len(args) > 2 {
+		count, err := strconv.Atoi(args[2])
+		if err != nil {
+			return nil, fmt.Errorf("ping watchdog count must be integer, not %s", args[2])
+		}
+		d.count = count
+	}
+	if len(args) > 3 {
+		timeout, err := strconv.Atoi(args[3])
+		if err != nil {
+			return nil, fmt.Errorf("ping watchdog timeout must be integer, not %s", args[3])
+		}
+		d.timeout = timeout
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	return d, nil
+}
+
+func (d *pingDog) check() error {
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return fmt.Errorf("ping to %s failed to open raw socket: %s", d.host, err)
+	}
+	defer conn.Close()
+
+	dst, err := net.ResolveIPAddr("ip4", d.host)
+	if err != nil {
+		return fmt.Errorf("ping to %s failed to resolve: %s", d.host, err)
+	}
+
+	id := os.Getpid() & 0xffff
+	deadline := time.Now().Add(time.Second * time.Duration(d.timeout))
+	for i := 0; i < d.count; i++ {
+		seq := i + 1
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Code: 0,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("governator")},
+		}
+		wb, err := msg.Marshal(nil)
+		if err != nil {
+			return fmt.Errorf("ping to %s failed to build packet: %s", d.host, err)
+		}
+		if _, err := conn.WriteTo(wb, dst); err != nil {
+			return fmt.Errorf("ping to %s failed to send: %s", d.host, err)
+		}
+		if err := d.awaitEcho(conn, id, seq, deadline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// awaitEcho reads from the shared ip4:icmp socket until it sees an echo
+// reply matching this probe's ID and sequence number, ignoring any other
+// ICMP traffic that socket happens to deliver in the meantime.
+func (d *pingDog) awaitEcho(conn *icmp.PacketConn, id, seq int, deadline time.Time) error {
+	rb := make([]byte, 1500)
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("ping to %s timed out", d.host)
+		}
+		conn.SetReadDeadline(deadline)
+		n, _, err := conn.ReadFrom(rb)
+		if err != nil {
+			return fmt.Errorf("ping to %s timed out: %s", d.host, err)
+		}
+		reply, err := icmp.ParseMessage(1, rb[:n])
+		if err != nil {
+			continue
+		}
+		if reply.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.ID != id || echo.Seq != seq {
+			continue
+		}
+		return nil
+	}
+}
+
+func (d *pingDog) String() string {
+	return fmt.Sprintf("ping: %s", d.host)
+}
+
+type scriptDog struct {
+	body string
+}
+
+func newScriptDog(args []string) (dog, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("script watchdog requires exactly one argument, %d given", len(args)-1)
+	}
+	return &scriptDog{body: args[1]}, nil
+}
+
+func (d *scriptDog) check() error {
+	f, err := ioutil.TempFile("", "governator-script-")
+	if err != nil {
+		return fmt.Errorf("script watchdog could not create temp file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(d.body); err != nil {
+		f.Close()
+		return fmt.Errorf("script watchdog could not write temp file: %s", err)
+	}
+	f.Close()
+	if err := os.Chmod(f.Name(), 0700); err != nil {
+		return fmt.Errorf("script watchdog could not chmod temp file: %s", err)
+	}
+	cmd := exec.Command(f.Name())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("script watchdog failed: %s, output: %s", err, out)
+	}
+	return nil
+}
+
+func (d *scriptDog) String() string {
+	return "script"
+}
+
+// watchdogPolicy controls how many failed checks it takes to trigger a
+// restart, how many successes it takes to forgive past failures, and how
+// aggressively the probe interval backs off while the service is unhealthy.
+type watchdogPolicy struct {
+	FailureThreshold int
+	SuccessReset     int
+	BackoffBase      time.Duration
+	BackoffCap       time.Duration
+	Jitter           float64
+}
+
+func defaultWatchdogPolicy() watchdogPolicy {
+	return watchdogPolicy{FailureThreshold: 1, SuccessReset: 1}
 }
 
 type Watchdog struct {
 	service *Service
 	dog     dog
+	dogKind string
+	policy  watchdogPolicy
+	restart restartPolicy
+	sink    eventSink
 	stop    chan bool
 	stopped chan bool
+
+	// sockets holds the pre-opened listeners for restart.Listen, opened
+	// once on first use and reused across every restart of this service.
+	sockets *listenerSet
+
+	// mu guards the counters below, which Start's background goroutine
+	// and an externally-invoked Check may otherwise touch concurrently.
+	mu                   sync.Mutex
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	attempt              int
+}
+
+// SetEventSink configures where per-check JSON events are written. target
+// is one of "stdout", "udp://host:port", or a file path; an empty target
+// disables event emission.
+func (w *Watchdog) SetEventSink(target string) error {
+	sink, err := newEventSink(target)
+	if err != nil {
+		return err
+	}
+	w.sink = sink
+	return nil
 }
 
 func (w *Watchdog) Start(s *Service, interval int) error {
 	w.service = s
 	w.stop = make(chan bool, 1)
 	w.stopped = make(chan bool, 1)
-	ticker := time.NewTicker(time.Second * time.Duration(interval))
+	if w.policy.FailureThreshold <= 0 {
+		w.policy.FailureThreshold = 1
+	}
+	if w.policy.SuccessReset <= 0 {
+		w.policy.SuccessReset = 1
+	}
+	if w.restart.StopSignal == nil {
+		w.restart = defaultRestartPolicy()
+	}
+	if len(w.restart.Listen) > 0 && w.sockets == nil {
+		sockets, err := openListeners(w.restart.Listen)
+		if err != nil {
+			return err
+		}
+		w.sockets = sockets
+	}
+	baseWait := time.Second * time.Duration(interval)
+	wait := baseWait
+	timer := time.NewTimer(wait)
 	go func() {
 		for {
 		stopWatchdog:
 			select {
 			case <-w.stop:
-				ticker.Stop()
+				timer.Stop()
 				w.stopped <- true
 				break stopWatchdog
-			case <-ticker.C:
+			case <-timer.C:
 				s.infof("running watchdog %s", w.dog)
-				if err := w.Check(); err != nil {
-					s.errorf("watchdog returned an error: %s", err)
-					if err := s.stopService(); err == nil {
-						s.startService()
-					}
-				} else {
+				started := time.Now()
+				ok, restarted, failures, err := w.check(false)
+				duration := time.Since(started)
+				w.recordCheck(s, ok, restarted, failures, err, duration)
+				if ok {
 					s.infof("watchdog finished successfully")
+					wait = baseWait
+				} else {
+					s.errorf("watchdog returned an error: %s", err)
+					wait = w.nextBackoff(wait, baseWait)
 				}
+				timer.Reset(withJitter(wait, w.policy.Jitter))
 			}
 		}
 	}()
 	return nil
 }
 
-func (w *Watchdog) Check() error {
-	return w.dog.check()
+// nextBackoff computes the delay before the next probe after a failed
+// check. Without a configured BackoffBase, the plain interval is reused
+// so behavior matches the pre-backoff watchdog.
+func (w *Watchdog) nextBackoff(current, baseWait time.Duration) time.Duration {
+	if w.policy.BackoffBase <= 0 {
+		return baseWait
+	}
+	next := current * 2
+	if current < w.policy.BackoffBase {
+		next = w.policy.BackoffBase
+	}
+	if w.policy.BackoffCap > 0 && next > w.policy.BackoffCap {
+		next = w.policy.BackoffCap
+	}
+	return next
+}
+
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || d <= 0 {
+		return d
+	}
+	spread := float64(d) * jitter
+	offset := (rand.Float64()*2 - 1) * spread
+	return d + time.Duration(offset)
+}
+
+// check runs the probe once and applies the failure threshold / success
+// reset policy. In dryRun mode it reports what the outcome would be
+// without writing w.consecutiveFailures, w.consecutiveSuccesses or
+// w.attempt, and it never calls stopService or startService - so a
+// dry-run Check has no effect on a concurrently running Start loop.
+// restarted reports whether this call triggered a stop/start cycle, and
+// failures is the consecutive-failure count this check observed,
+// captured before any reset so callers can still report the value that
+// tripped it. mu guards the counters since Start's goroutine and an
+// externally-invoked Check may call this concurrently.
+func (w *Watchdog) check(dryRun bool) (ok bool, restarted bool, failures int, err error) {
+	err = w.dog.check()
+
+	if dryRun {
+		w.mu.Lock()
+		failures, successes := w.consecutiveFailures, w.consecutiveSuccesses
+		w.mu.Unlock()
+		if err != nil {
+			return false, false, failures + 1, err
+		}
+		successes++
+		if successes >= w.policy.SuccessReset {
+			failures = 0
+		}
+		return true, false, failures, nil
+	}
+
+	w.mu.Lock()
+	w.attempt++
+	if err != nil {
+		w.consecutiveFailures++
+		w.consecutiveSuccesses = 0
+		failures = w.consecutiveFailures
+		tripped := w.consecutiveFailures >= w.policy.FailureThreshold
+		if tripped {
+			w.consecutiveFailures = 0
+		}
+		w.mu.Unlock()
+		if tripped {
+			if restartErr := gracefulRestart(w.service, w.restart, w.sockets); restartErr != nil {
+				w.service.errorf("graceful restart failed: %s", restartErr)
+			} else {
+				restarted = true
+			}
+		}
+		return false, restarted, failures, err
+	}
+	w.consecutiveSuccesses++
+	if w.consecutiveSuccesses >= w.policy.SuccessReset {
+		w.consecutiveFailures = 0
+	}
+	failures = w.consecutiveFailures
+	w.mu.Unlock()
+	return true, false, failures, nil
+}
+
+// Check runs the probe once and returns its raw pass/fail result. With
+// dryRun false it goes through the same failure-threshold accounting as
+// Start and restarts the service when the threshold is reached; with
+// dryRun true it leaves the counters untouched and never touches the
+// service.
+func (w *Watchdog) Check(dryRun bool) (bool, error) {
+	ok, _, _, err := w.check(dryRun)
+	return ok, err
+}
+
+// recordCheck publishes Prometheus metrics and a structured JSON event
+// for a single watchdog check. Existing s.infof/s.errorf logging happens
+// alongside this, so log-based consumers keep working unchanged. failures
+// is the consecutive-failure count as observed by check(), which may
+// already have been reset on w by the time this runs.
+func (w *Watchdog) recordCheck(s *Service, ok, restarted bool, failures int, err error, duration time.Duration) {
+	result := "ok"
+	if !ok {
+		result = "fail"
+	}
+	watchdogChecksTotal.WithLabelValues(s.Name, w.dogKind, result).Inc()
+	watchdogCheckDuration.WithLabelValues(s.Name, w.dogKind).Observe(duration.Seconds())
+	watchdogConsecutiveFailures.WithLabelValues(s.Name).Set(float64(failures))
+	if restarted {
+		serviceRestartsTotal.WithLabelValues(s.Name, "watchdog").Inc()
+	}
+	if w.sink == nil {
+		return
+	}
+	w.mu.Lock()
+	attempt := w.attempt
+	w.mu.Unlock()
+	event := watchdogEvent{
+		Timestamp:  time.Now(),
+		Service:    s.Name,
+		Dog:        w.dogKind,
+		OK:         ok,
+		DurationMS: duration.Milliseconds(),
+		Attempt:    attempt,
+	}
+	if err != nil {
+		event.Err = err.Error()
+	}
+	if sinkErr := w.sink.Emit(event); sinkErr != nil {
+		s.errorf("failed to emit watchdog event: %s", sinkErr)
+	}
 }
 
 func (w *Watchdog) Stop() {
@@ -168,6 +943,10 @@ func (w *Watchdog) Stop() {
 		w.stop = nil
 		w.stopped = nil
 	}
+	if w.sockets != nil {
+		w.sockets.Close()
+		w.sockets = nil
+	}
 }
 
 func (w *Watchdog) Parse(input string) error {
@@ -178,52 +957,92 @@ func (w *Watchdog) Parse(input string) error {
 	if err != nil {
 		return err
 	}
-	if len(args) > 0 {
-		switch args[0] {
-		case "run":
-			if len(args) == 1 {
-				return fmt.Errorf("run watchdog requires at least one argument")
-			}
-			w.dog = &runDog{args[1:]}
-		case "connect":
-			if len(args) != 2 && len(args) != 3 {
-				return fmt.Errorf("connect watchdog requires one or two arguments, %d given", len(args))
-			}
-			u, err := url.Parse(args[1])
+	positional, policy, err := splitPolicyArgs(args)
+	if err != nil {
+		return err
+	}
+	positional, restart, err := splitRestartArgs(positional)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		factory, ok := dogRegistry[positional[0]]
+		if !ok {
+			return fmt.Errorf("invalid watchdog %q - unknown probe type %q", input, positional[0])
+		}
+		d, err := factory(positional)
+		if err != nil {
+			return err
+		}
+		w.dog = d
+		w.dogKind = positional[0]
+		w.policy = policy
+		w.restart = restart
+	}
+	if w.dog == nil {
+		return fmt.Errorf("invalid watchdog %q - available watchdogs are %s", input, availableDogs())
+	}
+	return nil
+}
+
+// splitPolicyArgs pulls the trailing key=value policy tokens (threshold=,
+// reset=, backoff=, jitter=) out of a watchdog line, e.g.
+// "get https://x/health 5 threshold=3 backoff=2s..60s jitter=10%", leaving
+// the remaining positional arguments for the dog's own factory to parse.
+func splitPolicyArgs(args []string) ([]string, watchdogPolicy, error) {
+	policy := defaultWatchdogPolicy()
+	positional := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "threshold="):
+			v := strings.TrimPrefix(arg, "threshold=")
+			n, err := strconv.Atoi(v)
 			if err != nil {
-				return fmt.Errorf("invalid connect URL %q: %s", args[1], err)
-			}
-			if u.Scheme != "tcp" && u.Scheme != "udp" {
-				return fmt.Errorf("invalid connect URL scheme %q - must be tcp or udp", u.Scheme)
+				return nil, policy, fmt.Errorf("invalid threshold %q: %s", v, err)
 			}
-			if _, _, err := net.SplitHostPort(u.Host); err != nil {
-				return fmt.Errorf("address %q must specifiy a host and a port", u.Host)
-			}
-			timeout, err := getTimeout("connect", args)
+			policy.FailureThreshold = n
+		case strings.HasPrefix(arg, "reset="):
+			v := strings.TrimPrefix(arg, "reset=")
+			n, err := strconv.Atoi(v)
 			if err != nil {
-				return err
+				return nil, policy, fmt.Errorf("invalid reset %q: %s", v, err)
 			}
-			w.dog = &connectDog{u.Scheme, u.Host, timeout}
-		case "get":
-			if len(args) != 2 && len(args) != 3 {
-				return fmt.Errorf("get watchdog requires two or three arguments, %d given", len(args))
+			policy.SuccessReset = n
+		case strings.HasPrefix(arg, "backoff="):
+			v := strings.TrimPrefix(arg, "backoff=")
+			parts := strings.SplitN(v, "..", 2)
+			if len(parts) != 2 {
+				return nil, policy, fmt.Errorf("invalid backoff %q - expected base..cap, e.g. 2s..60s", v)
 			}
-			u, err := url.Parse(args[1])
+			base, err := time.ParseDuration(parts[0])
 			if err != nil {
-				return fmt.Errorf("invalid GET URL %q: %s", args[1], err)
+				return nil, policy, fmt.Errorf("invalid backoff base %q: %s", parts[0], err)
 			}
-			if u.Scheme != "http" && u.Scheme != "https" {
-				return fmt.Errorf("invalid GET URL scheme %q - must be http or https", u.Scheme)
+			backoffCap, err := time.ParseDuration(parts[1])
+			if err != nil {
+				return nil, policy, fmt.Errorf("invalid backoff cap %q: %s", parts[1], err)
 			}
-			timeout, err := getTimeout("get", args)
+			policy.BackoffBase = base
+			policy.BackoffCap = backoffCap
+		case strings.HasPrefix(arg, "jitter="):
+			v := strings.TrimSuffix(strings.TrimPrefix(arg, "jitter="), "%")
+			pct, err := strconv.ParseFloat(v, 64)
 			if err != nil {
-				return err
+				return nil, policy, fmt.Errorf("invalid jitter %q: %s", v, err)
 			}
-			w.dog = &getDog{args[1], timeout}
+			policy.Jitter = pct / 100
+		default:
+			positional = append(positional, arg)
 		}
 	}
-	if w.dog == nil {
-		return fmt.Errorf("invalid watchdog %q - available watchdogs are run, connect and get", input)
+	return positional, policy, nil
+}
+
+func availableDogs() string {
+	names := make([]string, 0, len(dogRegistry))
+	for name := range dogRegistry {
+		names = append(names, name)
 	}
-	return nil
+	sort.Strings(names)
+	return strings.Join(names, ", ")
 }
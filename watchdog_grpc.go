@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func init() {
+	RegisterDog("grpc", newGRPCDog)
+}
+
+// grpcDog implements the standard gRPC Health Checking Protocol
+// (grpc.health.v1.Health/Check) against a single service.
+type grpcDog struct {
+	addr    string
+	service string
+	tls     bool
+	timeout int
+}
+
+func newGRPCDog(args []string) (dog, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("grpc watchdog requires at least an address, %d given", len(args)-1)
+	}
+	if _, _, err := net.SplitHostPort(args[1]); err != nil {
+		return nil, fmt.Errorf("address %q must specify a host and a port", args[1])
+	}
+	d := &grpcDog{addr: args[1]}
+	for _, arg := range args[2:] {
+		key, value, hasValue := splitKeyValue(arg)
+		switch {
+		case !hasValue:
+			timeout, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("grpc watchdog: unrecognized argument %q", arg)
+			}
+			d.timeout = timeout
+		case key == "service":
+			d.service = value
+		case key == "tls":
+			d.tls = value == "true"
+		default:
+			return nil, fmt.Errorf("grpc watchdog: unrecognized argument %q", arg)
+		}
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	return d, nil
+}
+
+func (d *grpcDog) check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(d.timeout))
+	defer cancel()
+
+	creds := grpc.WithInsecure()
+	if d.tls {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+	}
+	conn, err := grpc.DialContext(ctx, d.addr, creds, grpc.WithBlock())
+	if err != nil {
+		return fmt.Errorf("grpc dial to %s failed: %s", d.addr, err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: d.service})
+	if err != nil {
+		return fmt.Errorf("grpc health check to %s failed: %s", d.addr, err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc health check to %s returned status %s", d.addr, resp.Status)
+	}
+	return nil
+}
+
+func (d *grpcDog) String() string {
+	if d.service != "" {
+		return fmt.Sprintf("grpc: %s (%s)", d.addr, d.service)
+	}
+	return fmt.Sprintf("grpc: %s", d.addr)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	watchdogChecksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "governator_watchdog_checks_total",
+		Help: "Total number of watchdog checks, labeled by service, probe type and result.",
+	}, []string{"service", "type", "result"})
+
+	watchdogCheckDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "governator_watchdog_duration_seconds",
+		Help: "Time spent running a single watchdog check.",
+	}, []string{"service", "type"})
+
+	watchdogConsecutiveFailures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "governator_watchdog_consecutive_failures",
+		Help: "Current number of consecutive failed checks for a service's watchdog.",
+	}, []string{"service"})
+
+	serviceRestartsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "governator_service_restarts_total",
+		Help: "Total number of service restarts, labeled by reason.",
+	}, []string{"service", "reason"})
+)
+
+// ServeWatchdogMetrics starts an HTTP listener exposing /metrics in the
+// Prometheus text format. It's meant to be called once from main with an
+// operator-configured listen address.
+func ServeWatchdogMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(addr, mux)
+}
+
+// watchdogEvent is one JSON line emitted per watchdog check.
+type watchdogEvent struct {
+	Timestamp  time.Time `json:"ts"`
+	Service    string    `json:"service"`
+	Dog        string    `json:"dog"`
+	OK         bool      `json:"ok"`
+	Err        string    `json:"err,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Attempt    int       `json:"attempt"`
+}
+
+// eventSink is anything that can receive a stream of watchdog events.
+type eventSink interface {
+	Emit(event watchdogEvent) error
+}
+
+// newEventSink builds an eventSink from a configured target: "stdout",
+// "udp://host:port", or a file path that is opened for appending. An
+// empty target yields a nil sink, which callers must treat as "disabled".
+func newEventSink(target string) (eventSink, error) {
+	switch {
+	case target == "":
+		return nil, nil
+	case target == "stdout":
+		return &writerSink{w: os.Stdout}, nil
+	case strings.HasPrefix(target, "udp://"):
+		conn, err := net.Dial("udp", strings.TrimPrefix(target, "udp://"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial udp event sink %q: %s", target, err)
+		}
+		return &writerSink{w: conn}, nil
+	default:
+		f, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open event sink file %q: %s", target, err)
+		}
+		return &writerSink{w: f}, nil
+	}
+}
+
+// writerSink emits one JSON object per line to an io.Writer.
+type writerSink struct {
+	w io.Writer
+}
+
+func (s *writerSink) Emit(event watchdogEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = s.w.Write(data)
+	return err
+}
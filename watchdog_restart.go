@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultGracefulTimeout = 10 * time.Second
+	defaultHammerTimeout   = 5 * time.Second
+)
+
+// restartPolicy controls how a watchdog-triggered restart tears the
+// service down and brings it back up: which signal asks it to shut
+// down, how long to wait before escalating to SIGKILL, and which
+// addresses (if any) should be handed off across the restart via
+// socket activation instead of being closed and rebound.
+type restartPolicy struct {
+	StopSignal      os.Signal
+	GracefulTimeout time.Duration
+	HammerTimeout   time.Duration
+	Listen          []string
+}
+
+func defaultRestartPolicy() restartPolicy {
+	return restartPolicy{
+		StopSignal:      syscall.SIGTERM,
+		GracefulTimeout: defaultGracefulTimeout,
+		HammerTimeout:   defaultHammerTimeout,
+	}
+}
+
+var restartSignals = map[string]os.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGINT":  syscall.SIGINT,
+	"SIGHUP":  syscall.SIGHUP,
+}
+
+// splitRestartArgs pulls the trailing key=value restart-policy tokens
+// (signal=, graceful-timeout=, hammer-timeout=, listen=) out of a
+// watchdog line, leaving the remaining positional arguments for the
+// dog's own factory to parse. listen= may repeat, once per address the
+// service listens on.
+func splitRestartArgs(args []string) ([]string, restartPolicy, error) {
+	policy := defaultRestartPolicy()
+	positional := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "signal="):
+			v := strings.ToUpper(strings.TrimPrefix(arg, "signal="))
+			sig, ok := restartSignals[v]
+			if !ok {
+				return nil, policy, fmt.Errorf("invalid signal %q - must be one of SIGTERM, SIGUSR2, SIGQUIT, SIGKILL, SIGINT, SIGHUP", v)
+			}
+			policy.StopSignal = sig
+		case strings.HasPrefix(arg, "graceful-timeout="):
+			v := strings.TrimPrefix(arg, "graceful-timeout=")
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, policy, fmt.Errorf("invalid graceful-timeout %q: %s", v, err)
+			}
+			policy.GracefulTimeout = d
+		case strings.HasPrefix(arg, "hammer-timeout="):
+			v := strings.TrimPrefix(arg, "hammer-timeout=")
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, policy, fmt.Errorf("invalid hammer-timeout %q: %s", v, err)
+			}
+			policy.HammerTimeout = d
+		case strings.HasPrefix(arg, "listen="):
+			policy.Listen = append(policy.Listen, strings.TrimPrefix(arg, "listen="))
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional, policy, nil
+}
+
+// listenerSet holds the TCP listeners governator pre-opens on a
+// service's behalf so a graceful restart can hand them to the new
+// child instead of closing and rebinding them, which is what would
+// otherwise cause connections to be refused during the swap. It is
+// opened once, the first time a restart policy configures Listen
+// addresses, and reused across every restart of that service.
+type listenerSet struct {
+	listeners []*net.TCPListener
+}
+
+// openListeners binds addrs and returns them as a listenerSet. On any
+// bind failure, the listeners already opened are closed before
+// returning the error.
+func openListeners(addrs []string) (*listenerSet, error) {
+	ls := &listenerSet{listeners: make([]*net.TCPListener, 0, len(addrs))}
+	for _, addr := range addrs {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			ls.Close()
+			return nil, fmt.Errorf("failed to listen on %q for socket handoff: %s", addr, err)
+		}
+		ls.listeners = append(ls.listeners, l.(*net.TCPListener))
+	}
+	return ls, nil
+}
+
+// Files returns the underlying file descriptors of the held listeners,
+// in the order they should be passed to the child starting at fd 3, as
+// systemd-style LISTEN_FDS/LISTEN_PID socket activation expects. Each
+// returned *os.File is a dup of the listener's fd; Service is
+// responsible for setting it in exec.Cmd.ExtraFiles and for setting
+// LISTEN_FDS/LISTEN_PID in the child's environment once it knows the
+// new process's pid.
+func (ls *listenerSet) Files() ([]*os.File, error) {
+	files := make([]*os.File, 0, len(ls.listeners))
+	for _, l := range ls.listeners {
+		f, err := l.File()
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract file descriptor from listener %s: %s", l.Addr(), err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// Close closes every listener in the set. It is safe to call on a set
+// that failed to fully open.
+func (ls *listenerSet) Close() {
+	for _, l := range ls.listeners {
+		l.Close()
+	}
+}
+
+// gracefulRestart tears the service down and brings it back up. The
+// stop itself - signal, graceful wait, escalation to SIGKILL - is
+// delegated to the Service's own stop API so its supervisor stays in
+// sync with the fact that a stop happened, rather than governator
+// reaching into the raw process and risking a double-start or racing
+// the supervisor's own reaper. When sockets is non-nil, the already
+// pre-opened listeners are handed to the new process so in-flight
+// connections on those addresses are not dropped during the swap.
+func gracefulRestart(s *Service, policy restartPolicy, sockets *listenerSet) error {
+	if err := s.stopServiceGracefully(policy.StopSignal, policy.GracefulTimeout, policy.HammerTimeout); err != nil {
+		return fmt.Errorf("graceful stop of %s failed: %s", s.Name, err)
+	}
+
+	if sockets == nil {
+		return s.startService()
+	}
+
+	files, err := sockets.Files()
+	if err != nil {
+		return fmt.Errorf("failed to prepare handoff sockets for %s: %s", s.Name, err)
+	}
+	return s.startServiceWithSockets(files)
+}
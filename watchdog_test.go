@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseStatusList(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{in: "200", want: []int{200}},
+		{in: "200,204", want: []int{200, 204}},
+		{in: "200-202", want: []int{200, 201, 202}},
+		{in: "200-202,301", want: []int{200, 201, 202, 301}},
+		{in: "", wantErr: true},
+		{in: "nope", wantErr: true},
+		{in: "200-nope", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := parseStatusList(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseStatusList(%q): expected error, got %v", c.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseStatusList(%q): unexpected error: %s", c.in, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Fatalf("parseStatusList(%q) = %v, want %v", c.in, got, c.want)
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("parseStatusList(%q) = %v, want %v", c.in, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSplitJSONExpectation(t *testing.T) {
+	path, value, err := splitJSONExpectation(".status==ok")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if path != ".status" || value != "ok" {
+		t.Errorf("got path=%q value=%q, want path=%q value=%q", path, value, ".status", "ok")
+	}
+	if _, _, err := splitJSONExpectation("no-separator"); err == nil {
+		t.Error("expected error for expression without ==")
+	}
+}
+
+func TestEvalJSONPath(t *testing.T) {
+	body := []byte(`{"status":"ok","nested":{"field":"value"}}`)
+	got, err := evalJSONPath(body, ".status")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != "ok" {
+		t.Errorf("got %q, want %q", got, "ok")
+	}
+	got, err = evalJSONPath(body, ".nested.field")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != "value" {
+		t.Errorf("got %q, want %q", got, "value")
+	}
+	if _, err := evalJSONPath(body, ".missing"); err == nil {
+		t.Error("expected error for missing field")
+	}
+	if _, err := evalJSONPath([]byte("not json"), ".status"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestSplitPolicyArgs(t *testing.T) {
+	args := []string{"get", "https://x/health", "5", "threshold=3", "backoff=2s..60s", "jitter=10%", "reset=2"}
+	positional, policy, err := splitPolicyArgs(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	wantPositional := []string{"get", "https://x/health", "5"}
+	if len(positional) != len(wantPositional) {
+		t.Fatalf("positional = %v, want %v", positional, wantPositional)
+	}
+	for i := range wantPositional {
+		if positional[i] != wantPositional[i] {
+			t.Errorf("positional[%d] = %q, want %q", i, positional[i], wantPositional[i])
+		}
+	}
+	if policy.FailureThreshold != 3 {
+		t.Errorf("FailureThreshold = %d, want 3", policy.FailureThreshold)
+	}
+	if policy.SuccessReset != 2 {
+		t.Errorf("SuccessReset = %d, want 2", policy.SuccessReset)
+	}
+	if policy.BackoffBase != 2*time.Second || policy.BackoffCap != 60*time.Second {
+		t.Errorf("backoff = %s..%s, want 2s..60s", policy.BackoffBase, policy.BackoffCap)
+	}
+	if policy.Jitter != 0.1 {
+		t.Errorf("Jitter = %v, want 0.1", policy.Jitter)
+	}
+
+	if _, _, err := splitPolicyArgs([]string{"get", "threshold=nope"}); err == nil {
+		t.Error("expected error for non-integer threshold")
+	}
+	if _, _, err := splitPolicyArgs([]string{"get", "backoff=bad"}); err == nil {
+		t.Error("expected error for malformed backoff")
+	}
+}
+
+func TestSplitRestartArgs(t *testing.T) {
+	args := []string{"run", "/bin/true", "signal=SIGUSR2", "graceful-timeout=3s", "hammer-timeout=1s"}
+	positional, policy, err := splitRestartArgs(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(positional) != 2 || positional[0] != "run" || positional[1] != "/bin/true" {
+		t.Errorf("positional = %v, want [run /bin/true]", positional)
+	}
+	if policy.GracefulTimeout != 3*time.Second || policy.HammerTimeout != 1*time.Second {
+		t.Errorf("timeouts = %s/%s, want 3s/1s", policy.GracefulTimeout, policy.HammerTimeout)
+	}
+	if _, _, err := splitRestartArgs([]string{"run", "signal=NOPE"}); err == nil {
+		t.Error("expected error for unknown signal")
+	}
+}
+
+func TestNewTLSDogTimeout(t *testing.T) {
+	d, err := newTLSDog([]string{"tls", "example.com:443", "30", "5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	td := d.(*tlsDog)
+	if td.days != 30 {
+		t.Errorf("days = %d, want 30", td.days)
+	}
+	if td.timeout != 5 {
+		t.Errorf("timeout = %d, want 5", td.timeout)
+	}
+}
+
+func TestNewPingDogTimeout(t *testing.T) {
+	d, err := newPingDog([]string{"ping", "example.com", "3", "5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pd := d.(*pingDog)
+	if pd.count != 3 {
+		t.Errorf("count = %d, want 3", pd.count)
+	}
+	if pd.timeout != 5 {
+		t.Errorf("timeout = %d, want 5", pd.timeout)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	w := &Watchdog{policy: watchdogPolicy{BackoffBase: 2 * time.Second, BackoffCap: 10 * time.Second}}
+	base := 1 * time.Second
+	got := w.nextBackoff(base, base)
+	if got != 2*time.Second {
+		t.Errorf("first backoff = %s, want 2s (BackoffBase floor)", got)
+	}
+	got = w.nextBackoff(8*time.Second, base)
+	if got != 10*time.Second {
+		t.Errorf("capped backoff = %s, want 10s", got)
+	}
+
+	noBackoff := &Watchdog{}
+	if got := noBackoff.nextBackoff(5*time.Second, base); got != base {
+		t.Errorf("without BackoffBase, nextBackoff = %s, want base interval %s", got, base)
+	}
+}
+
+func TestWithJitterBounds(t *testing.T) {
+	d := 10 * time.Second
+	for i := 0; i < 100; i++ {
+		got := withJitter(d, 0.1)
+		if got < 9*time.Second || got > 11*time.Second {
+			t.Fatalf("withJitter(%s, 0.1) = %s, out of expected [9s,11s] range", d, got)
+		}
+	}
+	if got := withJitter(d, 0); got != d {
+		t.Errorf("withJitter with no jitter = %s, want %s unchanged", got, d)
+	}
+}
+
+// toggleDog fails while failing is true, then succeeds.
+type toggleDog struct {
+	failing bool
+}
+
+func (d *toggleDog) check() error {
+	if d.failing {
+		return errors.New("probe failed")
+	}
+	return nil
+}
+
+func (d *toggleDog) String() string { return "toggle" }
+
+func TestWatchdogCheckThresholdAndReset(t *testing.T) {
+	probe := &toggleDog{failing: true}
+	w := &Watchdog{
+		dog:    probe,
+		policy: watchdogPolicy{FailureThreshold: 3, SuccessReset: 2},
+	}
+
+	// The real (non-dry-run) path accumulates consecutive failures and
+	// resets on SuccessReset consecutive passes.
+	for i := 1; i <= 2; i++ {
+		ok, _, failures, err := w.check(false)
+		if ok || err == nil {
+			t.Fatalf("check %d: want failure, got ok=%v err=%v", i, ok, err)
+		}
+		if failures != i || w.consecutiveFailures != i {
+			t.Fatalf("check %d: failures = %d / w.consecutiveFailures = %d, want %d", i, failures, w.consecutiveFailures, i)
+		}
+	}
+
+	probe.failing = false
+
+	ok, _, failures, err := w.check(false)
+	if !ok || err != nil {
+		t.Fatalf("expected success, got ok=%v err=%v", ok, err)
+	}
+	if failures != 2 || w.consecutiveFailures != 2 {
+		t.Fatalf("after one success (SuccessReset=2), consecutiveFailures = %d, want unchanged 2", w.consecutiveFailures)
+	}
+
+	ok, _, failures, err = w.check(false)
+	if !ok || err != nil {
+		t.Fatalf("expected success, got ok=%v err=%v", ok, err)
+	}
+	if failures != 0 || w.consecutiveFailures != 0 {
+		t.Fatalf("after SuccessReset consecutive successes, consecutiveFailures = %d, want 0", w.consecutiveFailures)
+	}
+}
+
+func TestWatchdogCheckDryRunIsSideEffectFree(t *testing.T) {
+	probe := &toggleDog{failing: true}
+	w := &Watchdog{
+		dog:    probe,
+		policy: watchdogPolicy{FailureThreshold: 3, SuccessReset: 2},
+	}
+
+	for i := 1; i <= 5; i++ {
+		ok, err := w.Check(true)
+		if ok || err == nil {
+			t.Fatalf("dry-run check %d: want failure, got ok=%v err=%v", i, ok, err)
+		}
+		if w.consecutiveFailures != 0 || w.consecutiveSuccesses != 0 || w.attempt != 0 {
+			t.Fatalf("dry-run check %d: counters mutated: failures=%d successes=%d attempt=%d, want all 0",
+				i, w.consecutiveFailures, w.consecutiveSuccesses, w.attempt)
+		}
+	}
+
+	probe.failing = false
+	ok, err := w.Check(true)
+	if !ok || err != nil {
+		t.Fatalf("expected success, got ok=%v err=%v", ok, err)
+	}
+	if w.consecutiveFailures != 0 || w.consecutiveSuccesses != 0 || w.attempt != 0 {
+		t.Fatalf("dry-run success: counters mutated: failures=%d successes=%d attempt=%d, want all 0",
+			w.consecutiveFailures, w.consecutiveSuccesses, w.attempt)
+	}
+}
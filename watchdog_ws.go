@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	RegisterDog("ws", newWSDog)
+	RegisterDog("wss", newWSDog)
+}
+
+// wsDog performs a WebSocket handshake against a URL and, when configured
+// to send a text frame, waits for a reply within the timeout. The reply
+// must equal expect when expect is set; otherwise any reply passes.
+type wsDog struct {
+	url     string
+	send    string
+	expect  string
+	timeout int
+}
+
+func newWSDog(args []string) (dog, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s watchdog requires a URL, %d given", args[0], len(args)-1)
+	}
+	u, err := url.Parse(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s URL %q: %s", args[0], args[1], err)
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid %s URL scheme %q - must be ws or wss", args[0], u.Scheme)
+	}
+	d := &wsDog{url: args[1]}
+	for _, arg := range args[2:] {
+		key, value, hasValue := splitKeyValue(arg)
+		switch {
+		case !hasValue:
+			timeout, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s watchdog: unrecognized argument %q", args[0], arg)
+			}
+			d.timeout = timeout
+		case key == "send":
+			d.send = value
+		case key == "expect":
+			d.expect = value
+		default:
+			return nil, fmt.Errorf("%s watchdog: unrecognized argument %q", args[0], arg)
+		}
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	return d, nil
+}
+
+func (d *wsDog) check() error {
+	dialer := websocket.Dialer{HandshakeTimeout: time.Second * time.Duration(d.timeout)}
+	conn, _, err := dialer.Dial(d.url, nil)
+	if err != nil {
+		return fmt.Errorf("websocket handshake with %s failed: %s", d.url, err)
+	}
+	defer conn.Close()
+
+	if d.send == "" {
+		return nil
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(d.send)); err != nil {
+		return fmt.Errorf("websocket write to %s failed: %s", d.url, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second * time.Duration(d.timeout)))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("websocket read from %s failed: %s", d.url, err)
+	}
+	if d.expect != "" && string(msg) != d.expect {
+		return fmt.Errorf("websocket response from %s was %q, expected %q", d.url, msg, d.expect)
+	}
+	return nil
+}
+
+func (d *wsDog) String() string {
+	return fmt.Sprintf("ws: %s", d.url)
+}